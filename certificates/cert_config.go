@@ -0,0 +1,74 @@
+package certificates
+
+import (
+	"crypto/x509/pkix"
+	"time"
+)
+
+// KeyAlgorithm selects the private key type a CertIssuer generates.
+type KeyAlgorithm string
+
+const (
+	KeyAlgorithmRSA2048   KeyAlgorithm = "RSA2048"
+	KeyAlgorithmRSA3072   KeyAlgorithm = "RSA3072"
+	KeyAlgorithmRSA4096   KeyAlgorithm = "RSA4096"
+	KeyAlgorithmECDSAP256 KeyAlgorithm = "ECDSAP256"
+	KeyAlgorithmECDSAP384 KeyAlgorithm = "ECDSAP384"
+	KeyAlgorithmEd25519   KeyAlgorithm = "Ed25519"
+)
+
+// CertConfig controls how SetupCertificates issues, verifies and renews the
+// webhook serving certificate.
+type CertConfig struct {
+	SecretName      string
+	SecretNamespace string
+	ServiceName     string
+
+	// KeyAlgorithm is the private key algorithm requested from the
+	// configured CertIssuer.
+	KeyAlgorithm KeyAlgorithm
+	// Validity is how long an issued certificate is requested to be valid
+	// for.
+	Validity time.Duration
+	// RenewBefore is how long before expiry a certificate is considered due
+	// for renewal.
+	RenewBefore time.Duration
+
+	// Subject is the requested certificate subject. CommonName defaults
+	// to "<ServiceName>.<SecretNamespace>.svc" if left empty.
+	Subject pkix.Name
+	// ExtraSANs are appended to the service's own DNS names.
+	ExtraSANs []string
+
+	// Targets are the resources the CABundle is injected into, in addition
+	// to any labeled via InjectCAFromAnnotation. Only used by the
+	// Secret-based delivery mode.
+	Targets []WebhookTarget
+
+	// FSCertDir switches delivery from a Secret to the filesystem: when set,
+	// the certificate and key are written to this directory instead, for
+	// use as an initContainer or sidecar alongside a webhook server that
+	// loads its certificate from disk.
+	FSCertDir string
+
+	// FSCertWatch, combined with FSCertDir, switches cert-gen from a
+	// one-shot initContainer check into a long-running sidecar: instead of
+	// checking the certificate once and exiting, it keeps checking and
+	// renewing it on disk for as long as the process runs.
+	FSCertWatch bool
+}
+
+// DefaultCertConfig returns the configuration cert-gen has historically
+// used: an RSA-2048 certificate valid for one year, renewed 10 days before
+// expiry, injected only into the APIRule CRD.
+func DefaultCertConfig() CertConfig {
+	return CertConfig{
+		SecretName:      secretName,
+		SecretNamespace: secretNamespace,
+		ServiceName:     serviceName,
+		KeyAlgorithm:    KeyAlgorithmRSA2048,
+		Validity:        certValidity,
+		RenewBefore:     certRenewBefore,
+		Targets:         DefaultWebhookTargets,
+	}
+}
@@ -0,0 +1,155 @@
+package certificates
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultFSCertDir is the directory controller-runtime's webhook server
+// reads its serving certificate from by default, used when cert-gen runs as
+// an initContainer or sidecar in the same pod.
+const DefaultFSCertDir = "/tmp/k8s-webhook-server/serving-certs"
+
+// CertWriter ensures a valid webhook serving certificate is delivered to its
+// destination, issuing a new one via the configured CertIssuer when the
+// existing one is missing or due for renewal.
+type CertWriter interface {
+	EnsureCertificate(ctx context.Context) error
+}
+
+// NewCertWriter builds the CertWriter matching cfg: the Secret-based writer
+// by default, or FSCertWriter when cfg.FSCertDir is set.
+func NewCertWriter(client ctrlclient.Client, cfg CertConfig) CertWriter {
+	if cfg.FSCertDir != "" {
+		return &FSCertWriter{Client: client, Dir: cfg.FSCertDir, Config: cfg}
+	}
+	return &SecretCertWriter{Client: client, Config: cfg}
+}
+
+// SecretCertWriter delivers the certificate via a Kubernetes Secret and
+// injects its CABundle into the configured webhook targets. This is the
+// original, and still default, delivery mode.
+type SecretCertWriter struct {
+	Client ctrlclient.Client
+	Config CertConfig
+}
+
+func (w *SecretCertWriter) EnsureCertificate(ctx context.Context) error {
+	return ensureWebhookCertificate(ctx, w.Client, w.Config)
+}
+
+// FSCertWriter writes tls.crt/tls.key (and a ca.crt) to a directory on disk
+// using an atomic symlink swap, so a webhook server reading its cert from
+// that directory never observes a partial write. This lets cert-gen run as
+// an initContainer or sidecar for webhook servers that load their
+// certificate from disk rather than from a mounted Secret.
+type FSCertWriter struct {
+	Client ctrlclient.Client
+	Dir    string
+	Config CertConfig
+}
+
+func (w *FSCertWriter) EnsureCertificate(ctx context.Context) error {
+	if w.isValid() {
+		return nil
+	}
+
+	certPEM, keyPEM, caPEM, err := generateWebhookCertificates(ctx, w.Client, w.Config)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate webhook certificates")
+	}
+	if len(caPEM) == 0 {
+		return errors.New("FSCertDir requires a CertIssuer that can provide a CA certificate for ca.crt; the configured issuer does not")
+	}
+
+	return w.writeAtomically(certPEM, keyPEM, caPEM)
+}
+
+func (w *FSCertWriter) isValid() bool {
+	certPEM, err := os.ReadFile(filepath.Join(w.Dir, certName))
+	if err != nil {
+		return false
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(w.Dir, keyName))
+	if err != nil {
+		return false
+	}
+	if err := verifyCertificate(certPEM, w.Config.RenewBefore); err != nil {
+		return false
+	}
+	if err := verifyKey(keyPEM); err != nil {
+		return false
+	}
+	return true
+}
+
+// writeAtomically mirrors the atomic-writer pattern used for ConfigMap and
+// Secret volume mounts: the new files are written into a fresh timestamped
+// staging directory, and only the single ..data symlink is swapped to point
+// at it, via a rename, which POSIX guarantees is atomic. The top-level
+// tls.crt/tls.key/ca.crt names are themselves stable symlinks into
+// ..data/<file> created once and never touched again, so a reader of any of
+// them either sees the old, complete set of files or the new one, never a
+// window where the name is missing.
+func (w *FSCertWriter) writeAtomically(certPEM, keyPEM, caPEM []byte) error {
+	stagingDir := filepath.Join(w.Dir, fmt.Sprintf("..%d", time.Now().UnixNano()))
+	if err := os.MkdirAll(stagingDir, 0o755); err != nil {
+		return errors.Wrap(err, "failed to create certificate staging directory")
+	}
+
+	files := map[string][]byte{
+		certName: certPEM,
+		keyName:  keyPEM,
+		caName:   caPEM,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(stagingDir, name), data, 0o644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", name)
+		}
+	}
+
+	dataLink := filepath.Join(w.Dir, dataDirName)
+	previousTarget, _ := os.Readlink(dataLink)
+
+	tmpLink := dataLink + ".tmp"
+	if err := os.RemoveAll(tmpLink); err != nil {
+		return errors.Wrap(err, "failed to clean up stale staging symlink")
+	}
+	if err := os.Symlink(stagingDir, tmpLink); err != nil {
+		return errors.Wrap(err, "failed to create staging symlink")
+	}
+	if err := os.Rename(tmpLink, dataLink); err != nil {
+		return errors.Wrap(err, "failed to swap in the new certificate directory")
+	}
+
+	for _, name := range []string{certName, keyName, caName} {
+		link := filepath.Join(w.Dir, name)
+		if err := os.Symlink(filepath.Join(dataDirName, name), link); err != nil && !os.IsExist(err) {
+			return errors.Wrapf(err, "failed to link %s", name)
+		}
+	}
+
+	if previousTarget != "" && previousTarget != stagingDir {
+		if err := os.RemoveAll(previousTarget); err != nil {
+			return errors.Wrap(err, "failed to clean up previous certificate staging directory")
+		}
+	}
+
+	return nil
+}
+
+const (
+	// caName is the file FSCertWriter writes the issuing CA certificate to,
+	// as returned by the configured CertIssuer alongside the leaf.
+	caName = "ca.crt"
+
+	// dataDirName is the symlink swapped in to point at the currently
+	// active staging directory.
+	dataDirName = "..data"
+)
@@ -3,18 +3,22 @@ package certificates
 import (
 	"context"
 	"crypto/x509"
-	"encoding/pem"
+	"encoding/base64"
 	"fmt"
 	"strings"
 	"time"
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/util/cert"
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -28,84 +32,189 @@ const (
 	secretNamespace = "cert-gen"
 	secretName      = "api-gateway-webhook-service"
 	serviceName     = "api-gateway-webhook-service"
+
+	// certRenewBefore is the default renewal threshold used by
+	// DefaultCertConfig.
+	certRenewBefore = 10 * 24 * time.Hour
+
+	// certValidity is the default certificate validity used by
+	// DefaultCertConfig.
+	certValidity = 365 * 24 * time.Hour
+
+	// fsCertCheckInterval is how often SetupCertificates re-checks the
+	// on-disk certificate when running in FSCertWatch sidecar mode.
+	fsCertCheckInterval = time.Hour
 )
 
-func SetupCertificates() string {
-	serverClient, err := ctrlclient.New(ctrl.GetConfigOrDie(), ctrlclient.Options{})
+// SetupCertificates delivers the webhook serving certificate per cfg. In the
+// default Secret-based mode it starts a controller that keeps the
+// certificate and the configured webhook targets' CABundle valid and in
+// sync for as long as the process runs, blocking until the manager is
+// stopped. When cfg.FSCertDir is set it instead writes the certificate to
+// disk: once and returns, for use as an initContainer, or (with
+// cfg.FSCertWatch) in a loop for as long as the process runs, for use as a
+// sidecar.
+func SetupCertificates(cfg CertConfig) string {
+	if cfg.FSCertDir != "" {
+		scheme := runtime.NewScheme()
+		if err := clientgoscheme.AddToScheme(scheme); err != nil {
+			return fmt.Sprintf("while adding client-go schema to k8s client: %s", err.Error())
+		}
+		if err := cmv1.AddToScheme(scheme); err != nil {
+			return fmt.Sprintf("while adding cert-manager schema to k8s client: %s", err.Error())
+		}
+		client, err := ctrlclient.New(ctrl.GetConfigOrDie(), ctrlclient.Options{Scheme: scheme})
+		if err != nil {
+			return fmt.Sprintf("failed to create a client: %s", err.Error())
+		}
+
+		writer := NewCertWriter(client, cfg)
+
+		if !cfg.FSCertWatch {
+			if err := writer.EnsureCertificate(context.TODO()); err != nil {
+				return fmt.Sprintf("failed to ensure webhook certificate on disk: %s", err.Error())
+			}
+			return "success"
+		}
+
+		ctx := ctrl.SetupSignalHandler()
+		ticker := time.NewTicker(fsCertCheckInterval)
+		defer ticker.Stop()
+		for {
+			if err := writer.EnsureCertificate(ctx); err != nil {
+				return fmt.Sprintf("failed to ensure webhook certificate on disk: %s", err.Error())
+			}
+			select {
+			case <-ctx.Done():
+				return "success"
+			case <-ticker.C:
+			}
+		}
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		// Only one replica may issue/rotate the certificate and write the
+		// CRD's CABundle at a time, otherwise two replicas (or two restart
+		// loops) racing can leave the Secret and the CABundle out of sync.
+		LeaderElection:          true,
+		LeaderElectionID:        "cert-gen-leader-election",
+		LeaderElectionNamespace: cfg.SecretNamespace,
+	})
 	if err != nil {
-		return fmt.Sprintf("failed to create a server client: %s", err.Error())
+		return fmt.Sprintf("failed to create a manager: %s", err.Error())
 	}
 
-	if err := apiextensionsv1.AddToScheme(serverClient.Scheme()); err != nil {
+	if err := apiextensionsv1.AddToScheme(mgr.GetScheme()); err != nil {
 		return fmt.Sprintf("while adding apiextensions.v1 schema to k8s client: %s", err.Error())
 	}
+	if err := cmv1.AddToScheme(mgr.GetScheme()); err != nil {
+		return fmt.Sprintf("while adding cert-manager schema to k8s client: %s", err.Error())
+	}
 
-	if err := ensureWebhookCertificate(context.TODO(), serverClient, secretName, secretNamespace, serviceName); err != nil {
-		return fmt.Sprintf("failed to ensure webhook secret: %s", err.Error())
+	reconciler := &WebhookCertificateReconciler{
+		Client: mgr.GetClient(),
+		Config: cfg,
+	}
+	if err := reconciler.SetupWithManager(mgr); err != nil {
+		return fmt.Sprintf("failed to set up webhook certificate reconciler: %s", err.Error())
+	}
+
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		return fmt.Sprintf("failed to start manager: %s", err.Error())
 	}
 
 	return "success"
 }
 
-func createCABundle(webhookNamespace string, serviceName string) ([]byte, []byte, error) {
-	cert, key, err := createCert(webhookNamespace, serviceName)
+func createCABundle(ctx context.Context, client ctrlclient.Client, cfg CertConfig) ([]byte, []byte, error) {
+	cert, key, err := createCert(ctx, client, cfg)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to crete cert")
 	}
 	return cert, key, nil
 }
 
-func addCertToConversionWebhook(ctx context.Context, client ctrlclient.Client, caBundle []byte) error {
-	crd := &apiextensionsv1.CustomResourceDefinition{}
-	err := client.Get(ctx, types.NamespacedName{Name: APIRuleCRDName}, crd)
-	if err != nil {
-		return errors.Wrap(err, "failed to get APIRule crd")
-	}
-
-	if contains, msg := containsConversionWebhookClientConfig(crd); !contains {
+// certGenFieldManager is the field manager used when server-side-applying
+// the CABundle onto a conversion webhook CRD target, so cert-gen only ever
+// owns that one field and doesn't fight other controllers managing the rest
+// of the CRD.
+const certGenFieldManager = "cert-gen"
+
+// addCertToConversionWebhook server-side-applies only
+// spec.conversion.webhook.clientConfig.caBundle onto the named CRD. The
+// patch is built as an unstructured object containing nothing but the
+// fields certGenFieldManager should own: a typed
+// apiextensionsv1.CustomResourceDefinition would marshal its other,
+// required Spec fields (group, names, scope, versions) at their zero
+// values, and ForceOwnership would hand ownership of those zero values to
+// cert-gen and strip them from whoever actually manages the CRD.
+func addCertToConversionWebhook(ctx context.Context, client ctrlclient.Client, caBundle []byte, crdName string) error {
+	current := &apiextensionsv1.CustomResourceDefinition{}
+	if err := client.Get(ctx, types.NamespacedName{Name: crdName}, current); err != nil {
+		return errors.Wrap(err, "while getting CRD to inject CaBundle into")
+	}
+
+	if contains, msg := containsConversionWebhookClientConfig(current); !contains {
 		return errors.Errorf("while validating CRD to be CaBundle injectable,: %s", msg)
 	}
 
-	crd.Spec.Conversion.Webhook.ClientConfig.CABundle = caBundle
-	err = client.Update(ctx, crd)
-	if err != nil {
+	patch := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiextensionsv1.SchemeGroupVersion.String(),
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]interface{}{
+			"name": crdName,
+		},
+		"spec": map[string]interface{}{
+			"conversion": map[string]interface{}{
+				"webhook": map[string]interface{}{
+					"clientConfig": map[string]interface{}{
+						"caBundle": base64.StdEncoding.EncodeToString(caBundle),
+					},
+				},
+			},
+		},
+	}}
+
+	// No optimistic-concurrency retry is needed here: with ForceOwnership,
+	// server-side apply resolves field-ownership conflicts itself rather
+	// than returning a 409 for the client to retry.
+	if err := client.Patch(ctx, patch, ctrlclient.Apply, ctrlclient.ForceOwnership, ctrlclient.FieldOwner(certGenFieldManager)); err != nil {
 		return errors.Wrap(err, "while updating CRD with Conversion webhook caBundle")
 	}
 	return nil
 }
 
-func ensureWebhookCertificate(ctx context.Context, client ctrlclient.Client, secretName, secretNamespace, serviceName string) error {
+func ensureWebhookCertificate(ctx context.Context, client ctrlclient.Client, cfg CertConfig) error {
 	secret := &corev1.Secret{}
 
-	err := client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: secretNamespace}, secret)
+	err := client.Get(ctx, types.NamespacedName{Name: cfg.SecretName, Namespace: cfg.SecretNamespace}, secret)
 	if err != nil && !apiErrors.IsNotFound(err) {
 		return errors.Wrap(err, "failed to get webhook secret")
 	}
 
 	if apiErrors.IsNotFound(err) {
-		return createSecret(ctx, client, secretName, secretNamespace, serviceName)
+		return createSecret(ctx, client, cfg)
 	}
 
-	if err := updateSecret(ctx, client, secret, serviceName); err != nil {
+	if err := updateSecret(ctx, client, secret, cfg); err != nil {
 		return errors.Wrap(err, "failed to update secret")
 	}
 	return nil
 }
 
-func createSecret(ctx context.Context, client ctrlclient.Client, name, namespace, serviceName string) error {
-	cert, key, err := buildCert(namespace, serviceName)
+func createSecret(ctx context.Context, client ctrlclient.Client, cfg CertConfig) error {
+	cert, key, err := buildCert(ctx, client, cfg)
 	if err != nil {
 		return errors.Wrap(err, "failed to build cert ")
 	}
 
-	secret := buildSecret(name, namespace, cert, key)
+	secret := buildSecret(cfg.SecretName, cfg.SecretNamespace, cert, key)
 
 	if err := client.Create(ctx, secret); err != nil {
 		return errors.Wrap(err, "failed to create secret")
 	}
 
-	err = addCertToConversionWebhook(ctx, client, cert)
-	if err != nil {
+	if err := injectCABundle(ctx, client, cert, cfg.Targets, cfg.SecretNamespace, cfg.SecretName); err != nil {
 		return err
 	}
 	return nil
@@ -126,8 +235,8 @@ func containsConversionWebhookClientConfig(crd *apiextensionsv1.CustomResourceDe
 	return true, ""
 }
 
-func createCert(webhookNamespace string, serviceName string) ([]byte, []byte, error) {
-	cert, key, err := buildCert(webhookNamespace, serviceName)
+func createCert(ctx context.Context, client ctrlclient.Client, cfg CertConfig) ([]byte, []byte, error) {
+	cert, key, err := buildCert(ctx, client, cfg)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to build certificate")
 	}
@@ -135,11 +244,11 @@ func createCert(webhookNamespace string, serviceName string) ([]byte, []byte, er
 	return cert, key, nil
 }
 
-func isValidSecret(s *corev1.Secret) (bool, error) {
+func isValidSecret(s *corev1.Secret, cfg CertConfig) (bool, error) {
 	if !hasRequiredKeys(s.Data) {
 		return false, nil
 	}
-	if err := verifyCertificate(s.Data[certName]); err != nil {
+	if err := verifyCertificate(s.Data[certName], cfg.RenewBefore); err != nil {
 		return false, err
 	}
 	if err := verifyKey(s.Data[keyName]); err != nil {
@@ -149,7 +258,7 @@ func isValidSecret(s *corev1.Secret) (bool, error) {
 	return true, nil
 }
 
-func verifyCertificate(c []byte) error {
+func verifyCertificate(c []byte, renewBefore time.Duration) error {
 	certificate, err := cert.ParseCertsPEM(c)
 	if err != nil {
 		return errors.Wrap(err, "failed to parse certificate data")
@@ -159,24 +268,25 @@ func verifyCertificate(c []byte) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to parse root certificate data")
 	}
-	// make sure the certificate is valid for the next 10 days. Otherwise it will be recreated.
-	_, err = certificate[0].Verify(x509.VerifyOptions{CurrentTime: time.Now().Add(10 * 24 * time.Hour), Roots: root})
+	// make sure the certificate is valid for the next renewBefore window. Otherwise it will be recreated.
+	_, err = certificate[0].Verify(x509.VerifyOptions{CurrentTime: time.Now().Add(renewBefore), Roots: root})
 	if err != nil {
 		return errors.Wrap(err, "certificate verification failed")
 	}
 	return nil
 }
 
-func verifyKey(k []byte) error {
-	b, _ := pem.Decode(k)
-	key, err := x509.ParsePKCS1PrivateKey(b.Bytes)
+// certNotAfter returns the expiry time of the first certificate found in the
+// given PEM data.
+func certNotAfter(c []byte) (time.Time, error) {
+	certificate, err := cert.ParseCertsPEM(c)
 	if err != nil {
-		return errors.Wrap(err, "failed to parse key data")
+		return time.Time{}, errors.Wrap(err, "failed to parse certificate data")
 	}
-	if err = key.Validate(); err != nil {
-		return errors.Wrap(err, "key verification failed")
+	if len(certificate) == 0 {
+		return time.Time{}, errors.New("no certificate found in PEM data")
 	}
-	return nil
+	return certificate[0].NotAfter, nil
 }
 
 func hasRequiredKeys(data map[string][]byte) bool {
@@ -191,8 +301,10 @@ func hasRequiredKeys(data map[string][]byte) bool {
 	return true
 }
 
-func buildCert(namespace, serviceName string) (cert []byte, key []byte, err error) {
-	cert, key, err = generateWebhookCertificates(serviceName, namespace)
+func buildCert(ctx context.Context, client ctrlclient.Client, cfg CertConfig) (cert []byte, key []byte, err error) {
+	// The Secret-based delivery mode only ever stores the leaf, so the
+	// issuer's CA certificate (if any) is discarded here.
+	cert, key, _, err = generateWebhookCertificates(ctx, client, cfg)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to generate webhook certificates")
 	}
@@ -200,46 +312,58 @@ func buildCert(namespace, serviceName string) (cert []byte, key []byte, err erro
 	return cert, key, nil
 }
 
-func updateSecret(ctx context.Context, client ctrlclient.Client, secret *corev1.Secret, serviceName string) error {
-	valid, _ := isValidSecret(secret)
-	if valid {
-		return nil
-	}
-
-	cert, key, err := createCABundle(secret.Namespace, serviceName)
-	if err != nil {
-		return errors.Wrap(err, "failed to ensure webhook secret")
-	}
+func updateSecret(ctx context.Context, client ctrlclient.Client, secret *corev1.Secret, cfg CertConfig) error {
+	valid, _ := isValidSecret(secret, cfg)
+	if !valid {
+		cert, key, err := createCABundle(ctx, client, cfg)
+		if err != nil {
+			return errors.Wrap(err, "failed to ensure webhook secret")
+		}
 
-	newSecret := buildSecret(secret.Name, secret.Namespace, cert, key)
+		newSecret := buildSecret(secret.Name, secret.Namespace, cert, key)
 
-	secret.Data = newSecret.Data
-	if err := client.Update(ctx, secret); err != nil {
-		return errors.Wrap(err, "failed to update secret")
+		secret.Data = newSecret.Data
+		if err := client.Update(ctx, secret); err != nil {
+			return errors.Wrap(err, "failed to update secret")
+		}
 	}
 
-	if err := addCertToConversionWebhook(ctx, client, cert); err != nil {
-		return errors.Wrap(err, "while adding CaBundle to Conversion Webhook for function CRD")
+	// Re-inject on every reconcile, even when the certificate itself didn't
+	// need renewing: the CRD/webhook config CABundle can be overwritten by
+	// something else (e.g. a CRD re-apply) independently of cert expiry, and
+	// that drift needs healing too.
+	if err := injectCABundle(ctx, client, secret.Data[certName], cfg.Targets, secret.Namespace, secret.Name); err != nil {
+		return errors.Wrap(err, "while injecting CaBundle into configured webhook targets")
 	}
 	return nil
 }
 
-func generateWebhookCertificates(serviceName, namespace string) ([]byte, []byte, error) {
-	altNames := serviceAltNames(serviceName, namespace)
-	return cert.GenerateSelfSignedCertKey(altNames[0], nil, altNames)
+// generateWebhookCertificates returns the leaf certificate and key, plus the
+// issuing CA certificate when the configured CertIssuer can provide one.
+func generateWebhookCertificates(ctx context.Context, client ctrlclient.Client, cfg CertConfig) (certPEM, keyPEM, caPEM []byte, err error) {
+	altNames := serviceAltNames(cfg.ServiceName, cfg.SecretNamespace, cfg.ExtraSANs)
+	issuer := newCertIssuer(client, cfg)
+
+	subject := cfg.Subject
+	if subject.CommonName == "" {
+		subject.CommonName = altNames[0]
+	}
+
+	return issuer.Issue(ctx, altNames, time.Now().Add(cfg.Validity), cfg.KeyAlgorithm, subject)
 }
 
-func serviceAltNames(serviceName, namespace string) []string {
+func serviceAltNames(serviceName, namespace string, extraSANs []string) []string {
 	namespacedServiceName := strings.Join([]string{serviceName, namespace}, ".")
 	commonName := strings.Join([]string{namespacedServiceName, "svc"}, ".")
 	serviceHostname := fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, namespace)
 
-	return []string{
+	altNames := []string{
 		commonName,
 		serviceName,
 		namespacedServiceName,
 		serviceHostname,
 	}
+	return append(altNames, extraSANs...)
 }
 
 func buildSecret(name, namespace string, cert []byte, key []byte) *corev1.Secret {
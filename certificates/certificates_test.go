@@ -0,0 +1,49 @@
+package certificates
+
+import (
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+)
+
+func TestServiceAltNames(t *testing.T) {
+	altNames := serviceAltNames("api-gateway-webhook-service", "cert-gen", []string{"extra.example.com"})
+
+	want := []string{
+		"api-gateway-webhook-service.cert-gen.svc",
+		"api-gateway-webhook-service",
+		"api-gateway-webhook-service.cert-gen",
+		"api-gateway-webhook-service.cert-gen.svc.cluster.local",
+		"extra.example.com",
+	}
+	if len(altNames) != len(want) {
+		t.Fatalf("serviceAltNames() = %v, want %v", altNames, want)
+	}
+	for i, name := range want {
+		if altNames[i] != name {
+			t.Errorf("serviceAltNames()[%d] = %q, want %q", i, altNames[i], name)
+		}
+	}
+}
+
+func TestCertNotAfterRoundTrip(t *testing.T) {
+	notAfter := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	certPEM, _, _, err := selfSignedIssuer{}.Issue(nil, []string{"example.com"}, notAfter, KeyAlgorithmECDSAP256, pkix.Name{CommonName: "example.com"})
+	if err != nil {
+		t.Fatalf("failed to issue test certificate: %v", err)
+	}
+
+	got, err := certNotAfter(certPEM)
+	if err != nil {
+		t.Fatalf("certNotAfter returned error: %v", err)
+	}
+	if !got.Equal(notAfter) {
+		t.Errorf("certNotAfter() = %v, want %v", got, notAfter)
+	}
+}
+
+func TestCertNotAfterRejectsGarbage(t *testing.T) {
+	if _, err := certNotAfter([]byte("not a certificate")); err == nil {
+		t.Fatal("expected an error for non-PEM data, got nil")
+	}
+}
@@ -0,0 +1,95 @@
+package certificates
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"time"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// certManagerIssuer requests a Certificate from cert-manager and waits for
+// the Secret it populates, so clusters that already run cert-manager can
+// issue webhook certs from their own CA instead of a self-signed one.
+type certManagerIssuer struct {
+	Client ctrlclient.Client
+
+	Namespace      string
+	IssuerName     string
+	IssuerKind     string
+	CertificateRef string
+}
+
+func (i certManagerIssuer) Issue(ctx context.Context, dnsNames []string, notAfter time.Time, keyAlg KeyAlgorithm, subject pkix.Name) ([]byte, []byte, []byte, error) {
+	secretName := i.CertificateRef + "-tls"
+
+	// The reconciler calls Issue again on every renewal, so the Certificate
+	// must be created-or-updated rather than always Created, or every
+	// renewal after the first would fail with AlreadyExists.
+	certificate := &cmv1.Certificate{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      i.CertificateRef,
+			Namespace: i.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, i.Client, certificate, func() error {
+		certificate.Spec = cmv1.CertificateSpec{
+			SecretName: secretName,
+			DNSNames:   dnsNames,
+			CommonName: subject.CommonName,
+			Duration:   &v1.Duration{Duration: time.Until(notAfter)},
+			PrivateKey: certManagerPrivateKey(keyAlg),
+			IssuerRef: cmmeta.ObjectReference{
+				Name: i.IssuerName,
+				Kind: i.IssuerKind,
+			},
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to create or update cert-manager Certificate")
+	}
+
+	secret := &corev1.Secret{}
+	err = wait.PollUntilContextTimeout(ctx, 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+		if err := i.Client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: i.Namespace}, secret); err != nil {
+			return false, nil
+		}
+		return hasRequiredKeys(secret.Data), nil
+	})
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "timed out waiting for cert-manager to populate the certificate Secret")
+	}
+
+	// cert-manager populates ca.crt in the target Secret alongside
+	// tls.crt/tls.key for issuers backed by a CA (e.g. a ClusterIssuer), so
+	// the real issuing CA can be distributed instead of the leaf.
+	return secret.Data[certName], secret.Data[keyName], secret.Data[caName], nil
+}
+
+// certManagerPrivateKey maps a KeyAlgorithm onto cert-manager's private key
+// spec, defaulting to RSA-2048 when alg is empty.
+func certManagerPrivateKey(alg KeyAlgorithm) *cmv1.CertificatePrivateKey {
+	switch alg {
+	case KeyAlgorithmRSA3072:
+		return &cmv1.CertificatePrivateKey{Algorithm: cmv1.RSAKeyAlgorithm, Size: 3072}
+	case KeyAlgorithmRSA4096:
+		return &cmv1.CertificatePrivateKey{Algorithm: cmv1.RSAKeyAlgorithm, Size: 4096}
+	case KeyAlgorithmECDSAP256:
+		return &cmv1.CertificatePrivateKey{Algorithm: cmv1.ECDSAKeyAlgorithm, Size: 256}
+	case KeyAlgorithmECDSAP384:
+		return &cmv1.CertificatePrivateKey{Algorithm: cmv1.ECDSAKeyAlgorithm, Size: 384}
+	case KeyAlgorithmEd25519:
+		return &cmv1.CertificatePrivateKey{Algorithm: cmv1.Ed25519KeyAlgorithm}
+	default:
+		return &cmv1.CertificatePrivateKey{Algorithm: cmv1.RSAKeyAlgorithm, Size: 2048}
+	}
+}
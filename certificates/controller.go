@@ -0,0 +1,107 @@
+package certificates
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// WebhookCertificateReconciler keeps the webhook serving certificate Secret
+// and the CABundle of every configured webhook target in sync. It re-issues
+// the certificate before it expires and heals a target's CABundle whenever
+// the target is changed by something else and loses it, instead of relying
+// on a one-shot check at process startup.
+type WebhookCertificateReconciler struct {
+	Client ctrlclient.Client
+	Config CertConfig
+}
+
+// Reconcile implements reconcile.Reconciler. It is triggered by changes to
+// the webhook Secret or to any watched webhook target (see
+// SetupWithManager), and always re-runs the full ensure/inject flow so they
+// stay consistent regardless of which one changed.
+func (r *WebhookCertificateReconciler) Reconcile(ctx context.Context, _ reconcile.Request) (ctrl.Result, error) {
+	writer := &SecretCertWriter{Client: r.Client, Config: r.Config}
+	if err := writer.EnsureCertificate(ctx); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to ensure webhook secret")
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, ctrlclient.ObjectKey{Name: r.Config.SecretName, Namespace: r.Config.SecretNamespace}, secret); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to get webhook secret")
+	}
+
+	return ctrl.Result{RequeueAfter: r.requeueAfter(secret)}, nil
+}
+
+// requeueAfter schedules the next reconciliation for the moment the
+// certificate's renewal window opens (NotAfter - RenewBefore), falling back
+// to a one-minute retry if the certificate can't be parsed or the window is
+// already open.
+func (r *WebhookCertificateReconciler) requeueAfter(secret *corev1.Secret) time.Duration {
+	notAfter, err := certNotAfter(secret.Data[certName])
+	if err != nil {
+		return time.Minute
+	}
+
+	if d := time.Until(notAfter.Add(-r.Config.RenewBefore)); d > 0 {
+		return d
+	}
+	return time.Minute
+}
+
+// SetupWithManager registers the reconciler to watch the webhook Secret and
+// every configured webhook target, so a rotation/deletion of the Secret or a
+// CABundle overwrite on any of its targets is healed without waiting for a
+// pod restart. Validating/MutatingWebhookConfigurations are also watched
+// generically via InjectCAFromAnnotation, since those aren't necessarily
+// listed in Config.Targets.
+func (r *WebhookCertificateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isWatchedSecret := predicate.NewPredicateFuncs(func(obj ctrlclient.Object) bool {
+		return obj.GetName() == r.Config.SecretName && obj.GetNamespace() == r.Config.SecretNamespace
+	})
+
+	watchedNames := map[WebhookTargetType]map[string]bool{}
+	for _, target := range r.Config.Targets {
+		if watchedNames[target.Type] == nil {
+			watchedNames[target.Type] = map[string]bool{}
+		}
+		watchedNames[target.Type][target.Name] = true
+	}
+
+	isWatchedCRD := predicate.NewPredicateFuncs(func(obj ctrlclient.Object) bool {
+		return watchedNames[TargetConversionCRD][obj.GetName()]
+	})
+
+	wantInjectCAFrom := r.Config.SecretNamespace + "/" + r.Config.SecretName
+	isWatchedValidatingWebhookConfiguration := predicate.NewPredicateFuncs(func(obj ctrlclient.Object) bool {
+		return watchedNames[TargetValidatingWebhookConfiguration][obj.GetName()] || obj.GetAnnotations()[InjectCAFromAnnotation] == wantInjectCAFrom
+	})
+	isWatchedMutatingWebhookConfiguration := predicate.NewPredicateFuncs(func(obj ctrlclient.Object) bool {
+		return watchedNames[TargetMutatingWebhookConfiguration][obj.GetName()] || obj.GetAnnotations()[InjectCAFromAnnotation] == wantInjectCAFrom
+	})
+
+	return builder.ControllerManagedBy(mgr).
+		For(&corev1.Secret{}, builder.WithPredicates(isWatchedSecret)).
+		Watches(&apiextensionsv1.CustomResourceDefinition{}, handler.EnqueueRequestsFromMapFunc(r.mapToSecretRequest), builder.WithPredicates(isWatchedCRD)).
+		Watches(&admissionregistrationv1.ValidatingWebhookConfiguration{}, handler.EnqueueRequestsFromMapFunc(r.mapToSecretRequest), builder.WithPredicates(isWatchedValidatingWebhookConfiguration)).
+		Watches(&admissionregistrationv1.MutatingWebhookConfiguration{}, handler.EnqueueRequestsFromMapFunc(r.mapToSecretRequest), builder.WithPredicates(isWatchedMutatingWebhookConfiguration)).
+		Complete(r)
+}
+
+// mapToSecretRequest always enqueues a reconcile request for the webhook
+// Secret, regardless of which watched object triggered it, since the
+// reconciler always ensures the Secret and every target's CABundle together.
+func (r *WebhookCertificateReconciler) mapToSecretRequest(_ context.Context, _ ctrlclient.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: ctrlclient.ObjectKey{Name: r.Config.SecretName, Namespace: r.Config.SecretNamespace}}}
+}
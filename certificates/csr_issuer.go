@@ -0,0 +1,113 @@
+package certificates
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"time"
+
+	"github.com/pkg/errors"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// csrIssuer requests a certificate via a Kubernetes
+// CertificateSigningRequest, analogous to the approach Istio's Chiron uses:
+// submit a CSR, optionally self-approve it if RBAC allows, then read back
+// the signed certificate. This lets cert-gen use whatever CA is wired up as
+// the cluster's CSR signer instead of generating its own.
+type csrIssuer struct {
+	Client ctrlclient.Client
+
+	// Name is the CertificateSigningRequest object's name. CSRs are
+	// cluster-scoped and their spec is immutable, so Name should be derived
+	// from the owning CertConfig's Secret to avoid colliding with another
+	// CertConfig's CSR.
+	Name string
+
+	SignerName  string
+	AutoApprove bool
+}
+
+// Issue does not return a CA certificate: the Kubernetes CSR API signs and
+// returns only the leaf, with no generic way to fetch the signer's CA from
+// here (it depends entirely on the cluster's SignerName implementation).
+// Callers that need a CA bundle, such as FSCertWriter's ca.crt, must treat
+// the nil caPEM this returns as this issuer being unsupported for their use.
+func (i csrIssuer) Issue(ctx context.Context, dnsNames []string, notAfter time.Time, keyAlg KeyAlgorithm, subject pkix.Name) ([]byte, []byte, []byte, error) {
+	key, err := generatePrivateKey(keyAlg)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to generate private key")
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  subject,
+		DNSNames: dnsNames,
+	}, key)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to create certificate signing request")
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+
+	expirationSeconds := int32(time.Until(notAfter).Seconds())
+	csr := &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: v1.ObjectMeta{Name: i.Name},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:           csrPEM,
+			SignerName:        i.SignerName,
+			ExpirationSeconds: &expirationSeconds,
+			Usages: []certificatesv1.KeyUsage{
+				certificatesv1.UsageServerAuth,
+				certificatesv1.UsageDigitalSignature,
+				certificatesv1.UsageKeyEncipherment,
+			},
+		},
+	}
+
+	// A CertificateSigningRequest's spec is immutable, so the reconciler's
+	// repeat calls to Issue on renewal can't reuse the previous object the
+	// way a cert-manager Certificate can be updated in place. Delete any
+	// leftover CSR from a prior issuance before creating the new one.
+	if err := i.Client.Delete(ctx, &certificatesv1.CertificateSigningRequest{ObjectMeta: v1.ObjectMeta{Name: i.Name}}); err != nil && !apiErrors.IsNotFound(err) {
+		return nil, nil, nil, errors.Wrap(err, "failed to delete previous CertificateSigningRequest")
+	}
+
+	if err := i.Client.Create(ctx, csr); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to create CertificateSigningRequest")
+	}
+
+	if i.AutoApprove {
+		csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+			Type:    certificatesv1.CertificateApproved,
+			Status:  corev1.ConditionTrue,
+			Reason:  "CertGenAutoApprove",
+			Message: "approved automatically by cert-gen",
+		})
+		if err := i.Client.SubResource("approval").Update(ctx, csr); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "failed to auto-approve CertificateSigningRequest")
+		}
+	}
+
+	err = wait.PollUntilContextTimeout(ctx, 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+		if err := i.Client.Get(ctx, types.NamespacedName{Name: csr.Name}, csr); err != nil {
+			return false, nil
+		}
+		return len(csr.Status.Certificate) > 0, nil
+	})
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "timed out waiting for CertificateSigningRequest to be signed")
+	}
+
+	keyPEM, err := encodePrivateKeyPEM(key)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to encode private key")
+	}
+	return csr.Status.Certificate, keyPEM, nil, nil
+}
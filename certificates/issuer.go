@@ -0,0 +1,100 @@
+package certificates
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// certIssuerEnvVar selects which CertIssuer implementation newCertIssuer
+// builds. Unset or unrecognized values fall back to the self-signed issuer.
+const certIssuerEnvVar = "CERT_GEN_ISSUER"
+
+// CertIssuer produces a serving certificate and its private key for the
+// given DNS names and subject, valid until notAfter. Implementations decide
+// how the certificate is actually produced: generated in-process, via
+// cert-manager, or via a Kubernetes CertificateSigningRequest. caPEM is the
+// certificate of the CA that signed certPEM, for callers that need to
+// distribute a trust bundle separately from the leaf (e.g. FSCertWriter's
+// ca.crt); it is nil when an issuer has no general way to determine its CA,
+// which such callers must treat as unsupported rather than guess.
+type CertIssuer interface {
+	Issue(ctx context.Context, dnsNames []string, notAfter time.Time, keyAlg KeyAlgorithm, subject pkix.Name) (certPEM, keyPEM, caPEM []byte, err error)
+}
+
+// newCertIssuer selects a CertIssuer implementation based on the
+// certIssuerEnvVar environment variable, defaulting to the self-signed
+// issuer so existing deployments keep working unchanged. The issuer's
+// backing objects are named after cfg's configured Secret, so distinct
+// CertConfigs (and their renewals) don't collide.
+func newCertIssuer(client ctrlclient.Client, cfg CertConfig) CertIssuer {
+	switch os.Getenv(certIssuerEnvVar) {
+	case "cert-manager":
+		return certManagerIssuer{
+			Client:         client,
+			Namespace:      cfg.SecretNamespace,
+			IssuerName:     os.Getenv("CERT_GEN_CERT_MANAGER_ISSUER"),
+			IssuerKind:     "ClusterIssuer",
+			CertificateRef: cfg.SecretName + "-cert-manager",
+		}
+	case "csr":
+		return csrIssuer{
+			Client:      client,
+			Name:        cfg.SecretName + "-csr",
+			SignerName:  os.Getenv("CERT_GEN_CSR_SIGNER_NAME"),
+			AutoApprove: os.Getenv("CERT_GEN_CSR_AUTO_APPROVE") == "true",
+		}
+	default:
+		return selfSignedIssuer{}
+	}
+}
+
+// selfSignedIssuer generates a self-signed certificate in-process. It is the
+// default issuer and requires no additional cluster permissions or external
+// PKI.
+type selfSignedIssuer struct{}
+
+func (selfSignedIssuer) Issue(_ context.Context, dnsNames []string, notAfter time.Time, keyAlg KeyAlgorithm, subject pkix.Name) ([]byte, []byte, []byte, error) {
+	priv, err := generatePrivateKey(keyAlg)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to generate private key")
+	}
+
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to generate certificate serial number")
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               subject,
+		DNSNames:              dnsNames,
+		NotBefore:             time.Now(),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, priv.Public(), priv)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to create self-signed certificate")
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	keyPEM, err := encodePrivateKeyPEM(priv)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to encode private key")
+	}
+
+	// The leaf is self-signed and IsCA, so it is its own CA certificate.
+	return certPEM, keyPEM, certPEM, nil
+}
@@ -0,0 +1,94 @@
+package certificates
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// generatePrivateKey creates a new private key for the requested algorithm,
+// defaulting to RSA-2048 when alg is empty.
+func generatePrivateKey(alg KeyAlgorithm) (crypto.Signer, error) {
+	switch alg {
+	case "", KeyAlgorithmRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyAlgorithmRSA3072:
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case KeyAlgorithmRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyAlgorithmECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyAlgorithmECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyAlgorithmEd25519:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	default:
+		return nil, errors.Errorf("unsupported key algorithm %q", alg)
+	}
+}
+
+// encodePrivateKeyPEM encodes priv as a PEM-wrapped PKCS#8 private key, the
+// one format every supported key algorithm marshals to.
+func encodePrivateKeyPEM(priv crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal private key")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// randomSerialNumber returns a random certificate serial number, as
+// required by RFC 5280.
+func randomSerialNumber() (*big.Int, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), 128)
+	return rand.Int(rand.Reader, limit)
+}
+
+// verifyKey checks that k is a parseable, valid private key. It accepts
+// PKCS#8 (the format used for RSA, ECDSA and Ed25519 keys generated by this
+// package) as well as the legacy PKCS#1 RSA format used by certificates
+// created before ECDSA/Ed25519 support was added.
+func verifyKey(k []byte) error {
+	b, _ := pem.Decode(k)
+	if b == nil {
+		return errors.New("failed to decode key PEM data")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(b.Bytes); err == nil {
+		return validatePrivateKey(key)
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(b.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse key data")
+	}
+	if err := key.Validate(); err != nil {
+		return errors.Wrap(err, "key verification failed")
+	}
+	return nil
+}
+
+func validatePrivateKey(key interface{}) error {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if err := k.Validate(); err != nil {
+			return errors.Wrap(err, "key verification failed")
+		}
+		return nil
+	case *ecdsa.PrivateKey:
+		return nil
+	case ed25519.PrivateKey:
+		return nil
+	default:
+		return errors.Errorf("unsupported private key type %T", key)
+	}
+}
@@ -0,0 +1,47 @@
+package certificates
+
+import (
+	"testing"
+)
+
+func TestGeneratePrivateKeyRoundTrip(t *testing.T) {
+	algs := []KeyAlgorithm{
+		"",
+		KeyAlgorithmRSA2048,
+		KeyAlgorithmRSA3072,
+		KeyAlgorithmRSA4096,
+		KeyAlgorithmECDSAP256,
+		KeyAlgorithmECDSAP384,
+		KeyAlgorithmEd25519,
+	}
+
+	for _, alg := range algs {
+		t.Run(string(alg), func(t *testing.T) {
+			priv, err := generatePrivateKey(alg)
+			if err != nil {
+				t.Fatalf("generatePrivateKey(%q) returned error: %v", alg, err)
+			}
+
+			keyPEM, err := encodePrivateKeyPEM(priv)
+			if err != nil {
+				t.Fatalf("encodePrivateKeyPEM returned error: %v", err)
+			}
+
+			if err := verifyKey(keyPEM); err != nil {
+				t.Fatalf("verifyKey rejected a freshly generated %q key: %v", alg, err)
+			}
+		})
+	}
+}
+
+func TestGeneratePrivateKeyUnsupportedAlgorithm(t *testing.T) {
+	if _, err := generatePrivateKey("not-a-real-algorithm"); err == nil {
+		t.Fatal("expected an error for an unsupported key algorithm, got nil")
+	}
+}
+
+func TestVerifyKeyRejectsGarbage(t *testing.T) {
+	if err := verifyKey([]byte("not a pem encoded key")); err == nil {
+		t.Fatal("expected an error for non-PEM data, got nil")
+	}
+}
@@ -0,0 +1,133 @@
+package certificates
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WebhookTargetType identifies the kind of resource a CABundle should be
+// injected into.
+type WebhookTargetType string
+
+const (
+	TargetConversionCRD                  WebhookTargetType = "ConversionCRD"
+	TargetValidatingWebhookConfiguration WebhookTargetType = "ValidatingWebhookConfiguration"
+	TargetMutatingWebhookConfiguration   WebhookTargetType = "MutatingWebhookConfiguration"
+
+	// InjectCAFromAnnotation marks a ValidatingWebhookConfiguration or
+	// MutatingWebhookConfiguration as wanting the CABundle of a given Secret
+	// injected into all of its webhook entries' clientConfig, without it
+	// having to be listed in the static target configuration.
+	// Value format: "<secretNamespace>/<secretName>".
+	InjectCAFromAnnotation = "cert-gen.kyma-project.io/inject-ca-from"
+)
+
+// WebhookTarget is a single resource that should receive the generated
+// CABundle.
+type WebhookTarget struct {
+	Type WebhookTargetType
+	Name string
+}
+
+// DefaultWebhookTargets preserves the historical behaviour of injecting only
+// into the APIRule CRD's conversion webhook.
+var DefaultWebhookTargets = []WebhookTarget{
+	{Type: TargetConversionCRD, Name: APIRuleCRDName},
+}
+
+// injectCABundle writes caBundle into every target in targets, plus any
+// ValidatingWebhookConfiguration/MutatingWebhookConfiguration annotated with
+// InjectCAFromAnnotation for secretNamespace/secretName.
+func injectCABundle(ctx context.Context, client ctrlclient.Client, caBundle []byte, targets []WebhookTarget, secretNamespace, secretName string) error {
+	labeled, err := discoverLabeledTargets(ctx, client, secretNamespace, secretName)
+	if err != nil {
+		return errors.Wrap(err, "failed to discover labeled webhook targets")
+	}
+
+	for _, target := range append(targets, labeled...) {
+		if err := injectCABundleInto(ctx, client, caBundle, target); err != nil {
+			return errors.Wrapf(err, "failed to inject CABundle into %s %q", target.Type, target.Name)
+		}
+	}
+	return nil
+}
+
+func injectCABundleInto(ctx context.Context, client ctrlclient.Client, caBundle []byte, target WebhookTarget) error {
+	switch target.Type {
+	case TargetConversionCRD:
+		return addCertToConversionWebhook(ctx, client, caBundle, target.Name)
+	case TargetValidatingWebhookConfiguration:
+		return injectValidatingWebhookConfiguration(ctx, client, caBundle, target.Name)
+	case TargetMutatingWebhookConfiguration:
+		return injectMutatingWebhookConfiguration(ctx, client, caBundle, target.Name)
+	default:
+		return errors.Errorf("unsupported webhook target type %q", target.Type)
+	}
+}
+
+func injectValidatingWebhookConfiguration(ctx context.Context, client ctrlclient.Client, caBundle []byte, name string) error {
+	webhookConfig := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := client.Get(ctx, types.NamespacedName{Name: name}, webhookConfig); err != nil {
+		return errors.Wrap(err, "failed to get ValidatingWebhookConfiguration")
+	}
+
+	for i := range webhookConfig.Webhooks {
+		webhookConfig.Webhooks[i].ClientConfig.CABundle = caBundle
+	}
+
+	if err := client.Update(ctx, webhookConfig); err != nil {
+		return errors.Wrap(err, "failed to update ValidatingWebhookConfiguration")
+	}
+	return nil
+}
+
+func injectMutatingWebhookConfiguration(ctx context.Context, client ctrlclient.Client, caBundle []byte, name string) error {
+	webhookConfig := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	if err := client.Get(ctx, types.NamespacedName{Name: name}, webhookConfig); err != nil {
+		return errors.Wrap(err, "failed to get MutatingWebhookConfiguration")
+	}
+
+	for i := range webhookConfig.Webhooks {
+		webhookConfig.Webhooks[i].ClientConfig.CABundle = caBundle
+	}
+
+	if err := client.Update(ctx, webhookConfig); err != nil {
+		return errors.Wrap(err, "failed to update MutatingWebhookConfiguration")
+	}
+	return nil
+}
+
+// discoverLabeledTargets finds ValidatingWebhookConfigurations and
+// MutatingWebhookConfigurations annotated with InjectCAFromAnnotation for
+// the given Secret, so operators don't have to enumerate every webhook
+// configuration in static target config.
+func discoverLabeledTargets(ctx context.Context, client ctrlclient.Client, secretNamespace, secretName string) ([]WebhookTarget, error) {
+	want := secretNamespace + "/" + secretName
+	var targets []WebhookTarget
+
+	validatingList := &admissionregistrationv1.ValidatingWebhookConfigurationList{}
+	if err := client.List(ctx, validatingList); err != nil {
+		return nil, errors.Wrap(err, "failed to list ValidatingWebhookConfigurations")
+	}
+	for _, wc := range validatingList.Items {
+		if wc.Annotations[InjectCAFromAnnotation] == want {
+			targets = append(targets, WebhookTarget{Type: TargetValidatingWebhookConfiguration, Name: wc.Name})
+		}
+	}
+
+	mutatingList := &admissionregistrationv1.MutatingWebhookConfigurationList{}
+	if err := client.List(ctx, mutatingList); err != nil {
+		return nil, errors.Wrap(err, "failed to list MutatingWebhookConfigurations")
+	}
+	for _, wc := range mutatingList.Items {
+		if wc.Annotations[InjectCAFromAnnotation] == want {
+			targets = append(targets, WebhookTarget{Type: TargetMutatingWebhookConfiguration, Name: wc.Name})
+		}
+	}
+
+	return targets, nil
+}